@@ -0,0 +1,258 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+)
+
+// 支持的 --action 取值
+const (
+	actionDelete   = "delete"
+	actionHardlink = "hardlink"
+	actionSymlink  = "symlink"
+	actionTrash    = "trash"
+	actionReport   = "report"
+)
+
+// Action 是对“如何处理一个重复文件”的抽象：不同模式（删除/链接/回收站/仅报告）
+// 各自实现 Apply，main 中的执行循环不需要关心具体差异。
+type Action interface {
+	// Name 返回 --action 中使用的标识，也用于日志输出
+	Name() string
+	// Apply 处理一个重复文件 dup，keep 是同组中被保留的那个文件
+	Apply(keep, dup FileEntry) error
+}
+
+// newAction 根据 --action 的取值构造对应的 Action 实现
+func newAction(name string) (Action, error) {
+	switch name {
+	case actionDelete:
+		return deleteAction{}, nil
+	case actionHardlink:
+		return hardlinkAction{}, nil
+	case actionSymlink:
+		return symlinkAction{}, nil
+	case actionTrash:
+		return trashAction{}, nil
+	case actionReport:
+		return reportAction{}, nil
+	default:
+		return nil, fmt.Errorf("未知的 --action 取值 '%s'，可选: %s/%s/%s/%s/%s",
+			name, actionDelete, actionHardlink, actionSymlink, actionTrash, actionReport)
+	}
+}
+
+// actionVerb 返回某个 action 对应的中文动词，用于执行日志
+func actionVerb(name string) string {
+	switch name {
+	case actionHardlink:
+		return "硬链接"
+	case actionSymlink:
+		return "软链接"
+	case actionTrash:
+		return "移入回收站"
+	case actionReport:
+		return "记录"
+	default:
+		return "删除"
+	}
+}
+
+// executeAction 对每个重复分组中的 Duplicates 依次执行 act.Apply，并汇总统计
+func executeAction(groups []DuplicateGroup, act Action) {
+	successCount := 0
+	failCount := 0
+
+	for _, g := range groups {
+		for _, dup := range g.Duplicates {
+			if err := act.Apply(g.Keep, dup); err != nil {
+				fmt.Printf("[%s失败] %s: %v\n", actionVerb(act.Name()), dup.Path, err)
+				failCount++
+				continue
+			}
+			fmt.Printf("[已%s] %s\n", actionVerb(act.Name()), dup.Path)
+			successCount++
+		}
+	}
+
+	fmt.Printf("\n统计: 成功 %d 个, 失败 %d 个\n", successCount, failCount)
+}
+
+// deleteAction 直接删除重复文件，等价于原来的 performDeletion
+type deleteAction struct{}
+
+func (deleteAction) Name() string { return actionDelete }
+
+func (deleteAction) Apply(keep, dup FileEntry) error {
+	return os.Remove(dup.Path)
+}
+
+// hardlinkAction 用硬链接指向保留文件替换重复文件，节省空间的同时保持路径可用
+type hardlinkAction struct{}
+
+func (hardlinkAction) Name() string { return actionHardlink }
+
+func (hardlinkAction) Apply(keep, dup FileEntry) error {
+	tmpPath := tempLinkPath(dup.Path)
+	if err := os.Link(keep.Path, tmpPath); err != nil {
+		return fmt.Errorf("创建硬链接失败（keep 和 dup 是否跨文件系统？）: %w", err)
+	}
+	if err := os.Rename(tmpPath, dup.Path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("用硬链接替换原文件失败: %w", err)
+	}
+	return nil
+}
+
+// symlinkAction 用软链接指向保留文件替换重复文件
+type symlinkAction struct{}
+
+func (symlinkAction) Name() string { return actionSymlink }
+
+func (symlinkAction) Apply(keep, dup FileEntry) error {
+	// 相对路径的链接目标会相对 dup 所在目录解析，所以链接目标统一用绝对路径
+	target, err := filepath.Abs(keep.Path)
+	if err != nil {
+		return fmt.Errorf("解析保留文件路径失败: %w", err)
+	}
+	tmpPath := tempLinkPath(dup.Path)
+	if err := os.Symlink(target, tmpPath); err != nil {
+		return fmt.Errorf("创建软链接失败: %w", err)
+	}
+	if err := os.Rename(tmpPath, dup.Path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("用软链接替换原文件失败: %w", err)
+	}
+	return nil
+}
+
+// tempLinkPath 返回和 dup 同目录、大概率不冲突的临时路径：先在这里把链接建好，
+// 确认成功后再 rename 覆盖 dup.Path，任何一步失败原文件都还在，不会出现
+// "删了原文件却没建成链接"的数据丢失
+func tempLinkPath(dupPath string) string {
+	return fmt.Sprintf("%s.dedup-tmp-%d", dupPath, os.Getpid())
+}
+
+// trashAction 把重复文件移动到系统回收站目录，而不是永久删除，操作可撤销
+type trashAction struct{}
+
+func (trashAction) Name() string { return actionTrash }
+
+func (trashAction) Apply(keep, dup FileEntry) error {
+	dir, err := trashDirFunc()
+	if err != nil {
+		return fmt.Errorf("定位回收站目录失败: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("创建回收站目录失败: %w", err)
+	}
+
+	dest := uniquePath(filepath.Join(dir, filepath.Base(dup.Path)))
+	if err := os.Rename(dup.Path, dest); err != nil {
+		if !isCrossDeviceErr(err) {
+			return fmt.Errorf("移动到回收站失败: %w", err)
+		}
+		// 回收站目录和 dup 不在同一个文件系统时 rename 必然失败，退化为拷贝+删除
+		if err := copyThenRemove(dup.Path, dest); err != nil {
+			return fmt.Errorf("移动到回收站失败（跨文件系统拷贝）: %w", err)
+		}
+	}
+	return nil
+}
+
+// isCrossDeviceErr 判断 err 是否是 rename 跨文件系统导致的 EXDEV
+func isCrossDeviceErr(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
+
+// copyThenRemove 把 src 拷贝到 dest 后删除 src，用于 os.Rename 跨文件系统失败时的兜底；
+// 拷贝过程中任何一步出错都不会删除 src，避免数据丢失
+func copyThenRemove(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dest)
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(dest)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dest)
+		return err
+	}
+
+	return os.Remove(src)
+}
+
+// trashDirFunc 是 trashDir 的可替换入口，测试里替换它来注入临时目录，
+// 避免测试真的写到用户的系统回收站
+var trashDirFunc = trashDir
+
+// trashDir 返回当前操作系统的回收站目录
+func trashDir() (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, ".Trash"), nil
+	case "windows":
+		// Windows 的回收站是系统 Shell API（SHFileOperation），没有简单的纯文件操作等价物，
+		// 这里退化为移动到系统临时目录下的固定子目录，行为上仍是“可恢复”而非永久删除
+		return filepath.Join(os.TempDir(), "RecycleBin"), nil
+	default:
+		// 遵循 XDG Trash 规范
+		if xdgData := os.Getenv("XDG_DATA_HOME"); xdgData != "" {
+			return filepath.Join(xdgData, "Trash", "files"), nil
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, ".local", "share", "Trash", "files"), nil
+	}
+}
+
+// uniquePath 如果目标路径已存在，追加数字后缀直到找到一个空闲路径，避免回收站内同名覆盖
+func uniquePath(path string) string {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return path
+	}
+
+	ext := filepath.Ext(path)
+	base := path[:len(path)-len(ext)]
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, i, ext)
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// reportAction 不触碰文件系统，只用于生成报告（配合 --output/--report 使用）
+type reportAction struct{}
+
+func (reportAction) Name() string { return actionReport }
+
+func (reportAction) Apply(keep, dup FileEntry) error {
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTempFile(t *testing.T, dir, name, content string) FileEntry {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+	return FileEntry{Name: name, Path: path, Size: int64(len(content))}
+}
+
+func TestHardlinkActionReplacesDupWithLink(t *testing.T) {
+	dir := t.TempDir()
+	keep := writeTempFile(t, dir, "keep.txt", "hello")
+	dup := writeTempFile(t, dir, "dup.txt", "hello")
+
+	if err := (hardlinkAction{}).Apply(keep, dup); err != nil {
+		t.Fatalf("Apply 失败: %v", err)
+	}
+
+	keepInfo, err := os.Stat(keep.Path)
+	if err != nil {
+		t.Fatalf("keep 文件应该还在: %v", err)
+	}
+	dupInfo, err := os.Stat(dup.Path)
+	if err != nil {
+		t.Fatalf("dup 路径应该被硬链接替换后继续存在: %v", err)
+	}
+	if !os.SameFile(keepInfo, dupInfo) {
+		t.Fatalf("dup 应该和 keep 指向同一个 inode")
+	}
+}
+
+func TestHardlinkActionLeavesDupIntactOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	dup := writeTempFile(t, dir, "dup.txt", "hello")
+
+	// keep 指向一个不存在的路径，os.Link 必然失败；dup 原文件不应该被动过
+	missingKeep := FileEntry{Name: "missing.txt", Path: filepath.Join(dir, "missing.txt")}
+
+	if err := (hardlinkAction{}).Apply(missingKeep, dup); err == nil {
+		t.Fatalf("期望 Apply 返回错误")
+	}
+
+	data, err := os.ReadFile(dup.Path)
+	if err != nil {
+		t.Fatalf("链接失败后 dup 原文件应该原封不动地保留: %v", err)
+	}
+	if string(data) != "hello" {
+		t.Fatalf("dup 内容被意外修改: %q", data)
+	}
+}
+
+func TestSymlinkActionReplacesDupWithLink(t *testing.T) {
+	dir := t.TempDir()
+	keep := writeTempFile(t, dir, "keep.txt", "hello")
+	dup := writeTempFile(t, dir, "dup.txt", "hello")
+
+	if err := (symlinkAction{}).Apply(keep, dup); err != nil {
+		t.Fatalf("Apply 失败: %v", err)
+	}
+
+	linkInfo, err := os.Lstat(dup.Path)
+	if err != nil {
+		t.Fatalf("dup 路径应该被软链接替换后继续存在: %v", err)
+	}
+	if linkInfo.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("dup 应该变成一个软链接")
+	}
+	target, err := os.Readlink(dup.Path)
+	if err != nil || target != keep.Path {
+		t.Fatalf("软链接目标应该是 keep.Path，got %q, err=%v", target, err)
+	}
+}
+
+func TestCopyThenRemoveMovesContentAndRemovesSource(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	dest := filepath.Join(dir, "dest.txt")
+	if err := os.WriteFile(src, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+
+	if err := copyThenRemove(src, dest); err != nil {
+		t.Fatalf("copyThenRemove 失败: %v", err)
+	}
+
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("dest 应该包含拷贝后的内容: %v", err)
+	}
+	if string(data) != "hello" {
+		t.Fatalf("dest 内容不符: %q", data)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Fatalf("拷贝成功后 src 应该被删除")
+	}
+}
+
+func TestCopyThenRemoveLeavesSrcIntactWhenDestInvalid(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	if err := os.WriteFile(src, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+
+	// dest 所在目录不存在，OpenFile 必然失败；src 不应该被动过
+	dest := filepath.Join(dir, "no-such-dir", "dest.txt")
+	if err := copyThenRemove(src, dest); err == nil {
+		t.Fatalf("期望 copyThenRemove 返回错误")
+	}
+
+	if _, err := os.Stat(src); err != nil {
+		t.Fatalf("拷贝失败后 src 应该原封不动: %v", err)
+	}
+}
+
+func TestTrashActionMovesDupIntoTrashDir(t *testing.T) {
+	dir := t.TempDir()
+	keep := writeTempFile(t, dir, "keep.txt", "hello")
+	dup := writeTempFile(t, dir, "dup.txt", "world")
+
+	trash := filepath.Join(dir, "trash")
+	origTrashDir := trashDirFunc
+	trashDirFunc = func() (string, error) { return trash, nil }
+	defer func() { trashDirFunc = origTrashDir }()
+
+	if err := (trashAction{}).Apply(keep, dup); err != nil {
+		t.Fatalf("Apply 失败: %v", err)
+	}
+
+	if _, err := os.Stat(dup.Path); !os.IsNotExist(err) {
+		t.Fatalf("dup 原路径应该不再存在")
+	}
+	data, err := os.ReadFile(filepath.Join(trash, "dup.txt"))
+	if err != nil {
+		t.Fatalf("回收站目录下应该有 dup.txt: %v", err)
+	}
+	if string(data) != "world" {
+		t.Fatalf("回收站内内容不符: %q", data)
+	}
+}
+
+func TestDeleteActionRemovesDup(t *testing.T) {
+	dir := t.TempDir()
+	keep := writeTempFile(t, dir, "keep.txt", "hello")
+	dup := writeTempFile(t, dir, "dup.txt", "hello")
+
+	if err := (deleteAction{}).Apply(keep, dup); err != nil {
+		t.Fatalf("Apply 失败: %v", err)
+	}
+	if _, err := os.Stat(dup.Path); !os.IsNotExist(err) {
+		t.Fatalf("dup 应该已经被删除")
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const defaultCacheFileName = "hashes.json"
+
+// cacheKey 标识一个文件的某个版本：绝对路径 + 大小 + 修改时间（纳秒）。
+// 只要文件内容或 mtime 变化，key 就会变化，旧记录自然失效，无需显式失效逻辑。
+// 前缀哈希还取决于 --prefix-bytes，这一项不在 key 里，由 hasher 记在缓存的前缀哈希值中。
+type cacheKey struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mod_time_ns"`
+}
+
+// cacheEntry 记录某个 (path,size,mtime) 对应的前缀哈希和（如果算过）完整哈希
+type cacheEntry struct {
+	PrefixHash string `json:"prefix_hash,omitempty"`
+	FullHash   string `json:"full_hash,omitempty"`
+}
+
+type cacheEntryPair struct {
+	Key   cacheKey   `json:"key"`
+	Entry cacheEntry `json:"entry"`
+}
+
+// hashCache 是一个进程内加锁保护、落盘为 JSON 文件的哈希缓存，用于在重复扫描
+// NAS 级别的大目录树时跳过未变化文件的重新哈希。
+type hashCache struct {
+	mu      sync.Mutex
+	path    string
+	enabled bool
+	dirty   bool
+	entries map[cacheKey]cacheEntry
+}
+
+// defaultCachePath 返回 --cache 的默认值：~/.cache/dedup_file/hashes.json
+// （Windows/macOS 下 os.UserCacheDir 会返回相应平台的缓存目录）
+func defaultCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(".", "dedup_file_cache", defaultCacheFileName)
+	}
+	return filepath.Join(dir, "dedup_file", defaultCacheFileName)
+}
+
+// newHashCache 加载 path 处已有的缓存文件（如果存在）；enabled=false 对应 --no-cache，
+// 此时所有读写都是空操作，调用方相当于完全绕过缓存。
+func newHashCache(path string, enabled bool) *hashCache {
+	c := &hashCache{path: path, enabled: enabled, entries: make(map[cacheKey]cacheEntry)}
+	if !enabled {
+		return c
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c // 文件不存在或不可读，视为空缓存
+	}
+
+	var raw []cacheEntryPair
+	if err := json.Unmarshal(data, &raw); err != nil {
+		fmt.Printf("哈希缓存文件解析失败，将重新建立: %v\n", err)
+		return c
+	}
+	for _, r := range raw {
+		c.entries[r.Key] = r.Entry
+	}
+	return c
+}
+
+// cacheKeyFor 根据文件路径、大小、修改时间构造缓存 key，路径统一转成绝对路径
+func cacheKeyFor(path string, size, modTimeNs int64) cacheKey {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	return cacheKey{Path: abs, Size: size, ModTime: modTimeNs}
+}
+
+// PrefixHash 返回缓存命中的前缀哈希
+func (c *hashCache) PrefixHash(key cacheKey) (string, bool) {
+	if !c.enabled {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || e.PrefixHash == "" {
+		return "", false
+	}
+	return e.PrefixHash, true
+}
+
+// FullHash 返回缓存命中的完整哈希
+func (c *hashCache) FullHash(key cacheKey) (string, bool) {
+	if !c.enabled {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || e.FullHash == "" {
+		return "", false
+	}
+	return e.FullHash, true
+}
+
+// StorePrefixHash 记录某个 key 的前缀哈希（仅写内存，落盘由 Flush 统一完成）
+func (c *hashCache) StorePrefixHash(key cacheKey, hash string) {
+	if !c.enabled {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entries[key]
+	e.PrefixHash = hash
+	c.entries[key] = e
+	c.dirty = true
+}
+
+// StoreFullHash 记录某个 key 的完整哈希
+func (c *hashCache) StoreFullHash(key cacheKey, hash string) {
+	if !c.enabled {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entries[key]
+	e.FullHash = hash
+	c.entries[key] = e
+	c.dirty = true
+}
+
+// Flush 把内存中的缓存写回磁盘；未启用或没有新增条目时直接返回
+func (c *hashCache) Flush() error {
+	if !c.enabled {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+
+	raw := make([]cacheEntryPair, 0, len(c.entries))
+	for k, e := range c.entries {
+		raw = append(raw, cacheEntryPair{Key: k, Entry: e})
+	}
+
+	data, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0o644)
+}
+
+// purgeCache 删除指定路径的缓存文件；文件本就不存在视为成功
+func purgeCache(path string) error {
+	err := os.Remove(path)
+	if err != nil && os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashCacheStoreThenHit(t *testing.T) {
+	c := newHashCache(filepath.Join(t.TempDir(), "hashes.json"), true)
+	key := cacheKeyFor("/tmp/a.txt", 100, 1)
+
+	if _, ok := c.PrefixHash(key); ok {
+		t.Fatalf("没存过的 key 不应该命中")
+	}
+
+	c.StorePrefixHash(key, "prefix-hash")
+	c.StoreFullHash(key, "full-hash")
+
+	if got, ok := c.PrefixHash(key); !ok || got != "prefix-hash" {
+		t.Fatalf("前缀哈希应该命中，got %q, ok=%v", got, ok)
+	}
+	if got, ok := c.FullHash(key); !ok || got != "full-hash" {
+		t.Fatalf("完整哈希应该命中，got %q, ok=%v", got, ok)
+	}
+}
+
+func TestHashCacheMissOnMtimeChange(t *testing.T) {
+	c := newHashCache(filepath.Join(t.TempDir(), "hashes.json"), true)
+
+	oldKey := cacheKeyFor("/tmp/a.txt", 100, 1)
+	c.StorePrefixHash(oldKey, "prefix-hash")
+
+	// 文件被修改后 mtime 变化，key 跟着变化，旧记录应该不再命中
+	newKey := cacheKeyFor("/tmp/a.txt", 100, 2)
+	if _, ok := c.PrefixHash(newKey); ok {
+		t.Fatalf("mtime 变化后不应该命中旧的缓存记录")
+	}
+}
+
+func TestHashCacheDisabledIsNoop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hashes.json")
+	c := newHashCache(path, false)
+	key := cacheKeyFor("/tmp/a.txt", 100, 1)
+
+	c.StorePrefixHash(key, "prefix-hash")
+	if _, ok := c.PrefixHash(key); ok {
+		t.Fatalf("--no-cache 模式下存取都应该是空操作")
+	}
+
+	if err := c.Flush(); err != nil {
+		t.Fatalf("Flush 失败: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("--no-cache 模式下不应该落盘任何文件")
+	}
+}
+
+func TestHashCacheFlushAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hashes.json")
+	c := newHashCache(path, true)
+	key := cacheKeyFor("/tmp/a.txt", 100, 1)
+	c.StorePrefixHash(key, "prefix-hash")
+
+	if err := c.Flush(); err != nil {
+		t.Fatalf("Flush 失败: %v", err)
+	}
+
+	reloaded := newHashCache(path, true)
+	if got, ok := reloaded.PrefixHash(key); !ok || got != "prefix-hash" {
+		t.Fatalf("重新加载后应该命中落盘的记录，got %q, ok=%v", got, ok)
+	}
+}
+
+func TestPurgeCacheRemovesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hashes.json")
+	if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+
+	if err := purgeCache(path); err != nil {
+		t.Fatalf("purgeCache 失败: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("purgeCache 后文件应该被删除")
+	}
+}
+
+func TestPurgeCacheMissingFileIsNotAnError(t *testing.T) {
+	if err := purgeCache(filepath.Join(t.TempDir(), "not-exist.json")); err != nil {
+		t.Fatalf("文件本就不存在时 purgeCache 不应该报错: %v", err)
+	}
+}
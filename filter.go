@@ -0,0 +1,83 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// fileFilter 决定一个文件是否参与去重扫描：--exclude、--min-size/--max-size、
+// --include-ext/--exclude-ext 都在这里统一判断，过滤发生在哈希之前。
+type fileFilter struct {
+	rootDir      string
+	excludeGlobs []string
+	minSize      int64
+	maxSize      int64 // <= 0 表示不限制
+	includeExts  map[string]bool
+	excludeExts  map[string]bool
+}
+
+func newFileFilter(rootDir string, excludeGlobs []string, minSize, maxSize int64, includeExts, excludeExts []string) *fileFilter {
+	return &fileFilter{
+		rootDir:      rootDir,
+		excludeGlobs: excludeGlobs,
+		minSize:      minSize,
+		maxSize:      maxSize,
+		includeExts:  extSet(includeExts),
+		excludeExts:  extSet(excludeExts),
+	}
+}
+
+func extSet(exts []string) map[string]bool {
+	if len(exts) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(exts))
+	for _, e := range exts {
+		set[normalizeExt(e)] = true
+	}
+	return set
+}
+
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// Allow 判断大小为 size 的 path 是否应该参与扫描
+func (f *fileFilter) Allow(path string, size int64) bool {
+	if f.minSize > 0 && size < f.minSize {
+		return false
+	}
+	if f.maxSize > 0 && size > f.maxSize {
+		return false
+	}
+
+	ext := normalizeExt(filepath.Ext(path))
+	if len(f.includeExts) > 0 && !f.includeExts[ext] {
+		return false
+	}
+	if f.excludeExts[ext] {
+		return false
+	}
+
+	rel, err := filepath.Rel(f.rootDir, path)
+	if err != nil {
+		rel = path
+	}
+	rel = filepath.ToSlash(rel)
+
+	for _, pattern := range f.excludeGlobs {
+		if matched, _ := filepath.Match(pattern, rel); matched {
+			return false
+		}
+		// 同时允许匹配文件名本身，方便 "*.tmp" 这类不关心目录层级的写法
+		if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
+			return false
+		}
+	}
+
+	return true
+}
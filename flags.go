@@ -0,0 +1,19 @@
+package main
+
+import "strings"
+
+// stringSliceFlag 实现 flag.Value，让同一个 flag 可以重复传入并累积成切片，
+// 用于 --priority-dir、--exclude、--include-ext、--exclude-ext 这类多值参数。
+type stringSliceFlag []string
+
+func (s *stringSliceFlag) String() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSliceFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
@@ -0,0 +1,11 @@
+package main
+
+// DuplicateGroup 描述一组内容相同的文件：Keep 是策略选出的保留文件，
+// Duplicates 是组内除 Keep 外的其余文件，它们会交给 Action 处理
+// （删除/硬链接/软链接/移入回收站/仅报告）。
+type DuplicateGroup struct {
+	Hash       string
+	Size       int64
+	Keep       FileEntry
+	Duplicates []FileEntry
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// hasher 是一组并发计算前缀哈希的 worker，数量由 --jobs 决定。
+// 每个 worker 独立消费 paths，把结果写入 index 的对应分片，互不阻塞。
+// 哈希结果在写入前会先查 cache，命中则跳过真正的文件读取。
+type hasher struct {
+	jobs   int
+	opts   scanOptions
+	cache  *hashCache
+	filter *fileFilter
+	policy *keepPolicy
+}
+
+// newHasher 创建一个 hasher，jobs 非正数时退化为单协程
+func newHasher(opts scanOptions, jobs int, cache *hashCache, filter *fileFilter, policy *keepPolicy) *hasher {
+	if jobs < 1 {
+		jobs = 1
+	}
+	return &hasher{jobs: jobs, opts: opts, cache: cache, filter: filter, policy: policy}
+}
+
+// prefixHashOf 返回 entry 的前缀哈希，优先从缓存读取，未命中才真正读取文件。
+// 前缀哈希取决于 prefixBytes，而缓存 key 里没有这一项，所以存入缓存的值带上
+// "<prefixBytes>:" 前缀，用另一个 --prefix-bytes 算出的旧值不会被当成命中。
+func (h *hasher) prefixHashOf(entry FileEntry) (string, error) {
+	key := cacheKeyFor(entry.Path, entry.Size, entry.ModTime)
+	tag := fmt.Sprintf("%d:", h.opts.prefixBytes)
+	if cached, ok := h.cache.PrefixHash(key); ok && strings.HasPrefix(cached, tag) {
+		return strings.TrimPrefix(cached, tag), nil
+	}
+
+	hash, err := calculatePrefixHash(entry.Path, h.opts.prefixBytes)
+	if err != nil {
+		return "", err
+	}
+	h.cache.StorePrefixHash(key, tag+hash)
+	return hash, nil
+}
+
+// fullHashOf 返回 entry 的完整哈希，优先从缓存读取，未命中才真正读取文件
+func (h *hasher) fullHashOf(entry FileEntry) (string, error) {
+	key := cacheKeyFor(entry.Path, entry.Size, entry.ModTime)
+	if hash, ok := h.cache.FullHash(key); ok {
+		return hash, nil
+	}
+
+	hash, err := calculateFileHash(entry.Path)
+	if err != nil {
+		return "", err
+	}
+	h.cache.StoreFullHash(key, hash)
+	return hash, nil
+}
+
+// Run 启动 worker 池消费 paths，把每个文件的前缀哈希结果写入 idx，
+// 所有 worker 处理完毕后返回。
+func (h *hasher) Run(paths <-chan string, idx *index) {
+	var wg sync.WaitGroup
+	wg.Add(h.jobs)
+
+	for i := 0; i < h.jobs; i++ {
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				info, err := os.Stat(path)
+				if err != nil {
+					continue
+				}
+
+				if !h.filter.Allow(path, info.Size()) {
+					continue
+				}
+
+				entry := FileEntry{
+					Name:    filepath.Base(path),
+					Path:    path,
+					Size:    info.Size(),
+					ModTime: info.ModTime().UnixNano(),
+				}
+
+				prefixHash, err := h.prefixHashOf(entry)
+				if err != nil {
+					fmt.Printf("计算前缀哈希失败 %s: %v\n", entry.Path, err)
+					continue
+				}
+
+				idx.Add(entry, prefixHash)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// confirmFullHashes 对前缀哈希碰撞的候选分组并发确认真正的重复。
+// 一个 candidateGroup 内的文件大小必然相同（分组 key 里已经包含 size），所以
+// 只要这个大小不超过 prefixBytes，前缀哈希本来就是整个文件的 SHA256，直接复用
+// 即可，不需要再完整读一遍文件确认；只有大小超过 prefixBytes 的分组才需要走
+// 完整哈希这一步。
+func (h *hasher) confirmFullHashes(groups []candidateGroup) []DuplicateGroup {
+	type result struct {
+		hash    string
+		entries []FileEntry
+	}
+
+	jobsCh := make(chan candidateGroup, len(groups))
+	for _, g := range groups {
+		jobsCh <- g
+	}
+	close(jobsCh)
+
+	resultsMu := sync.Mutex{}
+	var results []result
+
+	var wg sync.WaitGroup
+	wg.Add(h.jobs)
+
+	for i := 0; i < h.jobs; i++ {
+		go func() {
+			defer wg.Done()
+			for cg := range jobsCh {
+				if len(cg.entries) == 0 {
+					continue
+				}
+
+				if cg.entries[0].Size <= h.opts.prefixBytes {
+					// 前缀哈希已经覆盖了整个文件内容，等同于完整哈希，无需重新读取确认
+					resultsMu.Lock()
+					results = append(results, result{hash: cg.prefixHash, entries: cg.entries})
+					resultsMu.Unlock()
+					continue
+				}
+
+				byHash := make(map[string][]FileEntry)
+				for _, entry := range cg.entries {
+					hash, err := h.fullHashOf(entry)
+					if err != nil {
+						fmt.Printf("计算哈希失败 %s: %v\n", entry.Path, err)
+						continue
+					}
+					entry.fullRead = true
+					byHash[hash] = append(byHash[hash], entry)
+				}
+
+				resultsMu.Lock()
+				for hash, entries := range byHash {
+					if len(entries) > 1 {
+						results = append(results, result{hash: hash, entries: entries})
+					}
+				}
+				resultsMu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// 聚合阶段：在所有 worker 结束之后统一构建重复分组，发现日志不会和 worker 交错输出；
+	// 分组之间的先后顺序取决于 worker 完成的先后，每次运行不一定相同
+	duplicateGroups := make([]DuplicateGroup, 0, len(results))
+	for _, r := range results {
+		duplicateGroups = append(duplicateGroups, recordDuplicates(r.entries, r.hash, h.policy))
+	}
+	return duplicateGroups
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func mustWriteFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("写入 %s 失败: %v", path, err)
+	}
+}
+
+func runPipeline(t *testing.T, dir string, prefixBytes int64) []DuplicateGroup {
+	t.Helper()
+
+	opts := scanOptions{prefixBytes: prefixBytes, scope: "global"}
+	cache := newHashCache("", false)
+	filter := newFileFilter(dir, nil, 0, 0, nil, nil)
+	policy, err := newKeepPolicy(keepShortestName, nil, false)
+	if err != nil {
+		t.Fatalf("newKeepPolicy 失败: %v", err)
+	}
+
+	idx := newIndex(opts)
+	h := newHasher(opts, 4, cache, filter, policy)
+	h.Run(walkTree(dir), idx)
+
+	return h.confirmFullHashes(idx.candidateGroups())
+}
+
+// 覆盖 chunk0-1 的核心诉求：大小不超过 prefixBytes 的文件，前缀哈希本身就是
+// 完整哈希，不应该再触发一次完整读取（fullRead 应保持 false）。
+func TestConfirmFullHashesSkipsFullReadWhenSizeWithinPrefix(t *testing.T) {
+	dir := t.TempDir()
+
+	mustWriteFile(t, filepath.Join(dir, "small1.txt"), "ab")
+	mustWriteFile(t, filepath.Join(dir, "small2.txt"), "ab")
+
+	groups := runPipeline(t, dir, 64*1024)
+
+	if len(groups) != 1 {
+		t.Fatalf("期望恰好 1 个重复分组，got %d: %+v", len(groups), groups)
+	}
+
+	g := groups[0]
+	if g.Keep.fullRead {
+		t.Fatalf("小文件不应该触发完整读取确认（keep）")
+	}
+	for _, d := range g.Duplicates {
+		if d.fullRead {
+			t.Fatalf("小文件不应该触发完整读取确认（duplicate %s）", d.Path)
+		}
+	}
+}
+
+// 对照组：大小超过 prefixBytes 的真正重复文件，fullRead 应该是 true
+func TestConfirmFullHashesSetsFullReadWhenSizeExceedsPrefix(t *testing.T) {
+	dir := t.TempDir()
+
+	mustWriteFile(t, filepath.Join(dir, "big1.txt"), "AAAAxxxx")
+	mustWriteFile(t, filepath.Join(dir, "big2.txt"), "AAAAxxxx")
+
+	groups := runPipeline(t, dir, 4)
+
+	if len(groups) != 1 {
+		t.Fatalf("期望恰好 1 个重复分组，got %d: %+v", len(groups), groups)
+	}
+
+	g := groups[0]
+	for _, d := range g.Duplicates {
+		if !d.fullRead {
+			t.Fatalf("超过 prefixBytes 的重复文件应该经过完整哈希确认: %+v", d)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"hash/fnv"
+	"path/filepath"
+	"sync"
+)
+
+// indexShardCount 分片数量：分片越多，并发哈希 worker 之间的锁竞争越小
+const indexShardCount = 32
+
+// groupKey 决定文件在第一阶段按什么维度分组：
+// scope=dir 时还要带上所在目录，scope=global 时只按大小分组
+type groupKey struct {
+	dir  string
+	size int64
+}
+
+type indexShard struct {
+	mu   sync.Mutex
+	data map[groupKey]map[string][]FileEntry
+}
+
+// index 是分片哈希表：size(+dir) -> 前缀哈希 -> 候选文件列表。
+// 多个哈希 worker 可以并发写入不同分片而互不阻塞，最后统一聚合。
+type index struct {
+	opts   scanOptions
+	shards [indexShardCount]indexShard
+}
+
+func newIndex(opts scanOptions) *index {
+	idx := &index{opts: opts}
+	for i := range idx.shards {
+		idx.shards[i].data = make(map[groupKey]map[string][]FileEntry)
+	}
+	return idx
+}
+
+func (idx *index) shardFor(key groupKey) *indexShard {
+	h := fnv.New64a()
+	h.Write([]byte(key.dir))
+	sum := h.Sum64() ^ uint64(key.size)
+	return &idx.shards[sum%uint64(indexShardCount)]
+}
+
+// Add 记录一个已经算出前缀哈希的候选文件
+func (idx *index) Add(entry FileEntry, prefixHash string) {
+	key := groupKey{size: entry.Size}
+	if idx.opts.scope == "dir" {
+		key.dir = filepath.Dir(entry.Path)
+	}
+
+	shard := idx.shardFor(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	byPrefix := shard.data[key]
+	if byPrefix == nil {
+		byPrefix = make(map[string][]FileEntry)
+		shard.data[key] = byPrefix
+	}
+	byPrefix[prefixHash] = append(byPrefix[prefixHash], entry)
+}
+
+// candidateGroup 是一组前缀哈希相同的候选文件，连同它们共享的前缀哈希一起返回，
+// 这样后续确认阶段不需要为了复用这个值而重新读取文件
+type candidateGroup struct {
+	prefixHash string
+	entries    []FileEntry
+}
+
+// candidateGroups 返回所有前缀哈希下候选数 >= 2 的分组，供后续做完整哈希确认
+func (idx *index) candidateGroups() []candidateGroup {
+	var groups []candidateGroup
+	for i := range idx.shards {
+		shard := &idx.shards[i]
+		shard.mu.Lock()
+		for _, byPrefix := range shard.data {
+			for prefixHash, candidates := range byPrefix {
+				if len(candidates) > 1 {
+					groups = append(groups, candidateGroup{prefixHash: prefixHash, entries: candidates})
+				}
+			}
+		}
+		shard.mu.Unlock()
+	}
+	return groups
+}
@@ -2,196 +2,244 @@ package main
 
 import (
 	"bufio"
-	"cmp"
 	"crypto/sha256"
 	"encoding/hex"
 	"flag"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
-	"slices"
+	"runtime"
 	"strings"
 )
 
+// 默认预读字节数：只读取文件头部这么多字节用于初筛
+const defaultPrefixBytes = 64 * 1024
+
 // FileEntry 存储文件元数据
 type FileEntry struct {
-	Name string
-	Path string
-	Size int64
+	Name     string
+	Path     string
+	Size     int64
+	ModTime  int64 // 修改时间（UnixNano），用于哈希缓存的 key
+	fullRead bool  // 是否已经计算过完整文件哈希（而非仅前缀哈希）
+}
+
+// scanOptions 控制扫描/去重行为的参数集合
+type scanOptions struct {
+	prefixBytes int64
+	scope       string // "dir" 或 "global"
 }
 
 func main() {
 	// 1. 解析命令行参数
 	dirPtr := flag.String("dir", ".", "指定要遍历的根目录路径")
+	prefixBytesPtr := flag.Int64("prefix-bytes", defaultPrefixBytes, "初筛阶段读取的文件头部字节数")
+	scopePtr := flag.String("scope", "dir", "去重范围：dir（仅同目录内比较，默认）或 global（整棵目录树范围内比较）")
+	jobsPtr := flag.Int("jobs", runtime.NumCPU(), "并发哈希 worker 数量")
+	actionPtr := flag.String("action", actionDelete, "重复文件处理方式：delete/hardlink/symlink/trash/report")
+	dryRunPtr := flag.Bool("n", false, "dry-run：只打印将要执行的操作，不做任何实际改动")
+	outputPtr := flag.String("output", outputText, "结果输出格式：text/json/csv")
+	reportPtr := flag.String("report", "", "将结果按 --output 指定的格式写入报告文件")
+	fromReportPtr := flag.String("from-report", "", "从之前生成的 JSON 报告文件加载结果，跳过扫描和哈希计算")
+	cachePtr := flag.String("cache", defaultCachePath(), "哈希缓存文件路径")
+	noCachePtr := flag.Bool("no-cache", false, "禁用哈希缓存，每次都重新计算")
+	purgeCachePtr := flag.Bool("purge-cache", false, "清空哈希缓存文件后退出")
+	keepPtr := flag.String("keep", keepShortestName,
+		"keeper 选择策略：shortest-name/longest-name/oldest/newest/largest-path-depth/first-in-priority")
+	minSizePtr := flag.Int64("min-size", 0, "忽略小于该字节数的文件")
+	maxSizePtr := flag.Int64("max-size", 0, "忽略大于该字节数的文件（0 表示不限制）")
+	interactivePtr := flag.Bool("interactive-per-group", false, "当 --keep 策略出现并列时，交互式选择保留哪个文件")
+
+	var priorityDirs stringSliceFlag
+	flag.Var(&priorityDirs, "priority-dir", "优先作为 keeper 的目录（可重复指定）")
+	var excludeGlobs stringSliceFlag
+	flag.Var(&excludeGlobs, "exclude", "排除匹配该 glob 的文件，匹配相对路径（可重复指定）")
+	var includeExts stringSliceFlag
+	flag.Var(&includeExts, "include-ext", "只处理这些扩展名的文件（可重复指定）")
+	var excludeExts stringSliceFlag
+	flag.Var(&excludeExts, "exclude-ext", "排除这些扩展名的文件（可重复指定）")
+
 	flag.Parse()
 
+	if *purgeCachePtr {
+		if err := purgeCache(*cachePtr); err != nil {
+			fmt.Printf("清空哈希缓存失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("已清空哈希缓存: %s\n", *cachePtr)
+		return
+	}
+
 	rootDir := *dirPtr
 
-	// 验证目录是否存在
-	info, err := os.Stat(rootDir)
-	if err != nil || !info.IsDir() {
-		fmt.Printf("错误: 目录 '%s' 不存在或不是一个目录\n", rootDir)
+	if *scopePtr != "dir" && *scopePtr != "global" {
+		fmt.Printf("错误: --scope 取值必须是 dir 或 global，实际为 '%s'\n", *scopePtr)
 		os.Exit(1)
 	}
 
-	fmt.Println("--------------------------------------------------")
-	fmt.Printf("正在扫描目录: %s\n", rootDir)
-	fmt.Println("请稍候，正在计算哈希并比对同一层级文件...")
-	fmt.Println("--------------------------------------------------")
-
-	// 用于收集所有待删除的文件路径
-	var filesToDelete []string
-
-	// 2. 开始递归扫描，传入切片指针以收集数据
-	processDirectory(rootDir, &filesToDelete)
-
-	// 3. 扫描结束，检查结果
-	if len(filesToDelete) == 0 {
-		fmt.Println("\n太棒了！没有发现同一层级的重复文件。")
-		return
+	if *outputPtr != outputText && *outputPtr != outputJSON && *outputPtr != outputCSV {
+		fmt.Printf("错误: --output 取值必须是 text/json/csv，实际为 '%s'\n", *outputPtr)
+		os.Exit(1)
 	}
 
-	// 4. 列出待删除文件清单
-	fmt.Printf("\n--------------------------------------------------\n")
-	fmt.Printf("扫描完成！共发现 %d 个重复文件待清理：\n", len(filesToDelete))
-	fmt.Printf("--------------------------------------------------\n")
+	act, err := newAction(*actionPtr)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		os.Exit(1)
+	}
 
-	for i, path := range filesToDelete {
-		fmt.Printf("[%d] %s\n", i+1, path)
+	policy, err := newKeepPolicy(*keepPtr, []string(priorityDirs), *interactivePtr)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		os.Exit(1)
 	}
 
-	// 5. 交互式确认
-	fmt.Printf("\n警告: 以上文件将被永久删除且无法恢复。\n")
-	fmt.Print("是否确认删除？请输入 (y/n): ")
+	var groups []DuplicateGroup
 
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
-	input = strings.TrimSpace(strings.ToLower(input))
+	if *fromReportPtr != "" {
+		// 直接从报告文件恢复重复分组，跳过整个扫描/哈希阶段
+		fmt.Printf("正在从报告文件加载结果: %s\n", *fromReportPtr)
+		groups, err = loadReportFile(*fromReportPtr)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		opts := scanOptions{
+			prefixBytes: *prefixBytesPtr,
+			scope:       *scopePtr,
+		}
+
+		// 验证目录是否存在
+		info, statErr := os.Stat(rootDir)
+		if statErr != nil || !info.IsDir() {
+			fmt.Printf("错误: 目录 '%s' 不存在或不是一个目录\n", rootDir)
+			os.Exit(1)
+		}
 
-	if input == "y" {
-		fmt.Println("\n正在删除...")
-		performDeletion(filesToDelete)
 		fmt.Println("--------------------------------------------------")
-		fmt.Println("清理完成。")
-	} else {
-		fmt.Println("\n操作已取消，未删除任何文件。")
-	}
-}
+		fmt.Printf("正在扫描目录: %s (scope=%s, jobs=%d)\n", rootDir, opts.scope, *jobsPtr)
+		fmt.Println("请稍候，正在计算哈希并比对文件...")
+		fmt.Println("--------------------------------------------------")
 
-// performDeletion 批量执行删除
-func performDeletion(paths []string) {
-	successCount := 0
-	failCount := 0
+		cache := newHashCache(*cachePtr, !*noCachePtr)
+		filter := newFileFilter(rootDir, []string(excludeGlobs), *minSizePtr, *maxSizePtr,
+			[]string(includeExts), []string(excludeExts))
 
-	for _, path := range paths {
-		err := os.Remove(path)
-		if err != nil {
-			fmt.Printf("[删除失败] %s: %v\n", path, err)
-			failCount++
-		} else {
-			fmt.Printf("[已删除] %s\n", path)
-			successCount++
+		// 2. 并发扫描 + 哈希：生产者遍历目录树，worker 池并行计算前缀哈希并写入分片索引
+		// （filter 会在进入索引前就剔除不满足条件的文件）
+		idx := newIndex(opts)
+		h := newHasher(opts, *jobsPtr, cache, filter, policy)
+		h.Run(walkTree(rootDir), idx)
+
+		// 3. 聚合阶段：只对前缀哈希发生碰撞的候选分组并发计算完整哈希，得到确认后的重复分组
+		groups = h.confirmFullHashes(idx.candidateGroups())
+
+		if err := cache.Flush(); err != nil {
+			fmt.Printf("写入哈希缓存失败: %v\n", err)
 		}
 	}
-	fmt.Printf("\n统计: 成功删除 %d 个, 失败 %d 个\n", successCount, failCount)
-}
 
-// processDirectory 递归处理目录
-// 注意：这里传入的是 *[]string 指针，以便在递归中追加数据
-func processDirectory(dirPath string, toDelete *[]string) {
-	entries, err := os.ReadDir(dirPath)
-	if err != nil {
-		fmt.Printf("无法读取目录 %s: %v\n", dirPath, err)
-		return
+	totalDuplicates := 0
+	for _, g := range groups {
+		totalDuplicates += len(g.Duplicates)
 	}
 
-	var subDirs []string
-	filesBySize := make(map[int64][]FileEntry)
+	// 4. 扫描结束，检查结果
+	if totalDuplicates == 0 {
+		fmt.Println("\n太棒了！没有发现重复文件。")
+		return
+	}
 
-	// 分类：收集子目录，并将文件按大小分组
-	for _, entry := range entries {
-		fullPath := filepath.Join(dirPath, entry.Name())
+	// 5. 按 --output 指定的格式输出结果；--report 则在交互确认之前把同样的结果落盘，
+	// 方便用户把报告喂给其他工具，或者后续配合 --from-report 重新执行 action 阶段
+	fmt.Printf("\n--------------------------------------------------\n")
+	fmt.Printf("扫描完成！共发现 %d 个重复文件，将执行 [%s]：\n", totalDuplicates, act.Name())
+	fmt.Printf("--------------------------------------------------\n")
 
-		if entry.IsDir() {
-			subDirs = append(subDirs, fullPath)
-			continue
-		}
+	if err := writeReport(os.Stdout, groups, *outputPtr); err != nil {
+		fmt.Printf("输出结果失败: %v\n", err)
+	}
 
-		info, err := entry.Info()
-		if err != nil {
-			continue
+	if *reportPtr != "" {
+		if err := writeReportFile(groups, *outputPtr, *reportPtr); err != nil {
+			fmt.Printf("写入报告文件失败: %v\n", err)
+		} else {
+			fmt.Printf("报告已写入: %s\n", *reportPtr)
 		}
-
-		size := info.Size()
-		filesBySize[size] = append(filesBySize[size], FileEntry{
-			Name: entry.Name(),
-			Path: fullPath,
-			Size: size,
-		})
 	}
 
-	// 处理当前目录下的重复文件
-	detectAndCollect(filesBySize, toDelete)
-
-	// 递归处理子目录
-	for _, subDir := range subDirs {
-		processDirectory(subDir, toDelete)
+	// dry-run 只打印将要执行的操作，不做任何实际改动，也不需要交互确认
+	if *dryRunPtr {
+		fmt.Println("\n(dry-run) 以上操作均未实际执行。")
+		return
 	}
-}
 
-// detectAndCollect 检测哈希并将待删除文件加入列表
-func detectAndCollect(filesBySize map[int64][]FileEntry, toDelete *[]string) {
-	for _, entries := range filesBySize {
-		if len(entries) < 2 {
-			continue
-		}
+	// report 模式不修改文件系统，直接执行、无需确认
+	if act.Name() == actionReport {
+		executeAction(groups, act)
+		return
+	}
 
-		filesByHash := make(map[string][]FileEntry)
+	// 6. 交互式确认
+	fmt.Printf("\n警告: 以上文件将执行 [%s] 操作，其中 delete 操作不可恢复。\n", act.Name())
+	fmt.Print("是否确认继续？请输入 (y/n): ")
 
-		for _, entry := range entries {
-			hash, err := calculateFileHash(entry.Path)
-			if err != nil {
-				fmt.Printf("计算哈希失败 %s: %v\n", entry.Path, err)
-				continue
-			}
-			filesByHash[hash] = append(filesByHash[hash], entry)
-		}
+	reader := bufio.NewReader(os.Stdin)
+	input, _ := reader.ReadString('\n')
+	input = strings.TrimSpace(strings.ToLower(input))
 
-		for hash, duplicates := range filesByHash {
-			if len(duplicates) > 1 {
-				// 发现重复，筛选出要删除的
-				recordDuplicates(duplicates, hash, toDelete)
-			}
-		}
+	if input == "y" {
+		fmt.Println("\n正在处理...")
+		executeAction(groups, act)
+		fmt.Println("--------------------------------------------------")
+		fmt.Println("处理完成。")
+	} else {
+		fmt.Println("\n操作已取消，未做任何修改。")
 	}
 }
 
-// recordDuplicates 决定保留哪个，将其余的加入待删除列表
-func recordDuplicates(files []FileEntry, hash string, toDelete *[]string) {
-	// 排序逻辑：名字短的排前面，长度一样按字母序
-	slices.SortFunc(files, func(a, b FileEntry) int {
-		// 1. 优先比较文件名长度
-		if n := cmp.Compare(len(a.Name), len(b.Name)); n != 0 {
-			return n
-		}
-		// 2. 长度相同时，比较文件名字母序
-		return cmp.Compare(a.Name, b.Name)
-	})
+// recordDuplicates 按 policy 从一组内容相同的文件中选出保留项，
+// 返回描述该分组的 DuplicateGroup
+func recordDuplicates(files []FileEntry, hash string, policy *keepPolicy) DuplicateGroup {
+	keep := policy.resolveKeep(files)
 
-	keep := files[0]
-	discardCandidates := files[1:]
+	duplicates := make([]FileEntry, 0, len(files)-1)
+	for _, f := range files {
+		if f.Path != keep.Path {
+			duplicates = append(duplicates, f)
+		}
+	}
 
 	// 打印实时的发现日志（可选，为了让用户知道进度）
 	fmt.Printf("发现重复 (Hash: %s...): 保留 [%s]\n", hash[:8], keep.Name)
 
-	// 将要删除的文件路径加入总列表
-	for _, f := range discardCandidates {
-		*toDelete = append(*toDelete, f.Path)
+	return DuplicateGroup{
+		Hash:       hash,
+		Size:       keep.Size,
+		Keep:       keep,
+		Duplicates: duplicates,
+	}
+}
+
+// calculatePrefixHash 只读取文件头部 prefixBytes 字节计算 SHA256，用作初筛。
+// 如果文件本身比 prefixBytes 小，则等价于完整哈希。
+func calculatePrefixHash(filePath string, prefixBytes int64) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.LimitReader(file, prefixBytes)); err != nil {
+		return "", err
 	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
-// calculateFileHash 计算文件的 SHA256 哈希
+// calculateFileHash 计算文件的完整 SHA256 哈希
 func calculateFileHash(filePath string) (string, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// 覆盖 scanner/hasher/index 并发流水线的端到端正确性：大小不同、前缀相同但
+// 完整内容不同、以及真正重复的文件在多个 worker 并发处理下都应该被正确区分。
+func TestConfirmFullHashesDetectsDuplicatesAcrossWorkers(t *testing.T) {
+	dir := t.TempDir()
+
+	// 前 4 字节相同，但完整内容不同，大小超过 prefixBytes=4，必须靠完整哈希排除
+	mustWriteFile(t, filepath.Join(dir, "a.txt"), "AAAAxxxx")
+	mustWriteFile(t, filepath.Join(dir, "b.txt"), "AAAAyyyy")
+	// 和 a.txt 完全一致的真正重复
+	mustWriteFile(t, filepath.Join(dir, "c.txt"), "AAAAxxxx")
+	mustWriteFile(t, filepath.Join(dir, "unique.txt"), "zzzz")
+
+	groups := runPipeline(t, dir, 4)
+
+	var found bool
+	for _, g := range groups {
+		names := map[string]bool{g.Keep.Name: true}
+		for _, d := range g.Duplicates {
+			names[d.Name] = true
+		}
+		if names["a.txt"] && names["c.txt"] {
+			found = true
+			if names["b.txt"] {
+				t.Fatalf("b.txt 前缀相同但内容不同，不应该被判定为重复: %+v", g)
+			}
+			if len(g.Duplicates) != 1 {
+				t.Fatalf("a.txt/c.txt 分组应该恰好有 1 个 duplicate，got %d", len(g.Duplicates))
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("应该检测到 a.txt 和 c.txt 是重复文件，实际分组: %+v", groups)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// --keep 支持的取值
+const (
+	keepShortestName     = "shortest-name"
+	keepLongestName      = "longest-name"
+	keepOldest           = "oldest"
+	keepNewest           = "newest"
+	keepLargestPathDepth = "largest-path-depth"
+	keepFirstInPriority  = "first-in-priority"
+)
+
+// keepPolicy 把一组重复文件排出优先级顺序，排在最前面的会被选为 keeper。
+// 不管选了哪种 mode，位于 --priority-dir 下的文件永远优先于其余文件。
+type keepPolicy struct {
+	mode         string
+	priorityDirs []string
+	interactive  bool
+}
+
+func newKeepPolicy(mode string, priorityDirs []string, interactive bool) (*keepPolicy, error) {
+	switch mode {
+	case keepShortestName, keepLongestName, keepOldest, keepNewest, keepLargestPathDepth, keepFirstInPriority:
+	default:
+		return nil, fmt.Errorf("未知的 --keep 取值 '%s'", mode)
+	}
+	return &keepPolicy{mode: mode, priorityDirs: priorityDirs, interactive: interactive}, nil
+}
+
+// priorityRank 返回文件相对 --priority-dir 列表的优先级序号，数值越小越优先；
+// 不在任何 priority-dir 下的文件排在所有 priority-dir 之后
+func (p *keepPolicy) priorityRank(entry FileEntry) int {
+	abs, err := filepath.Abs(entry.Path)
+	if err != nil {
+		abs = entry.Path
+	}
+	for i, dir := range p.priorityDirs {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			absDir = dir
+		}
+		if abs == absDir || strings.HasPrefix(abs, absDir+string(filepath.Separator)) {
+			return i
+		}
+	}
+	return len(p.priorityDirs)
+}
+
+// criterion 只按 --keep 本身的判断依据比较 a、b（priority-dir 排名 + 所选 mode
+// 对应的字段），不含字母序兜底。返回 0 代表这两个文件在该策略下真正打平手——
+// 这是 --interactive-per-group 判断是否需要提示用户的唯一依据。
+func (p *keepPolicy) criterion(a, b FileEntry) int {
+	// --priority-dir 永远优先于普通的 --keep 策略
+	if n := cmp.Compare(p.priorityRank(a), p.priorityRank(b)); n != 0 {
+		return n
+	}
+
+	switch p.mode {
+	case keepLongestName:
+		return cmp.Compare(len(b.Name), len(a.Name))
+	case keepOldest:
+		return cmp.Compare(a.ModTime, b.ModTime)
+	case keepNewest:
+		return cmp.Compare(b.ModTime, a.ModTime)
+	case keepLargestPathDepth:
+		return cmp.Compare(pathDepth(b.Path), pathDepth(a.Path))
+	case keepFirstInPriority:
+		// 只依赖上面的 priorityRank，没有额外字段可比较
+		return 0
+	default: // keepShortestName
+		return cmp.Compare(len(a.Name), len(b.Name))
+	}
+}
+
+// compare 实现 slices.SortFunc 需要的比较函数：返回负数表示 a 应该排在 b 前面，
+// 即 a 更适合作为 keeper。在 criterion 打平手时退化为字母序，只是为了让排序
+// 结果稳定、可复现——不代表策略本身认为两者有区别，判断打平手请用 criterion。
+func (p *keepPolicy) compare(a, b FileEntry) int {
+	if n := p.criterion(a, b); n != 0 {
+		return n
+	}
+	return cmp.Compare(a.Name, b.Name)
+}
+
+func pathDepth(path string) int {
+	return strings.Count(filepath.Clean(path), string(filepath.Separator))
+}
+
+// resolveKeep 按策略排序后决定最终 keeper：如果开启了 --interactive-per-group
+// 且排序结果中前两名打平手，交由用户从和第一名打平手的这些文件中手动选择
+func (p *keepPolicy) resolveKeep(files []FileEntry) FileEntry {
+	slices.SortFunc(files, p.compare)
+
+	if p.interactive && len(files) > 1 && p.criterion(files[0], files[1]) == 0 {
+		tied := 2
+		for tied < len(files) && p.criterion(files[0], files[tied]) == 0 {
+			tied++
+		}
+		return promptKeepChoice(files[:tied])
+	}
+	return files[0]
+}
+
+// promptKeepChoice 在策略打平手时，让用户从命令行手动选择要保留的文件
+func promptKeepChoice(files []FileEntry) FileEntry {
+	fmt.Println("\n以下文件在当前 --keep 策略下优先级相同，请选择要保留的文件：")
+	for i, f := range files {
+		fmt.Printf("  [%d] %s\n", i+1, f.Path)
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("请输入编号 (1-%d): ", len(files))
+		input, _ := reader.ReadString('\n')
+		choice, err := strconv.Atoi(strings.TrimSpace(input))
+		if err == nil && choice >= 1 && choice <= len(files) {
+			return files[choice-1]
+		}
+		fmt.Println("无效输入，请重新选择。")
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestKeepPolicyCriterionTieIgnoresAlphabeticalFallback(t *testing.T) {
+	policy, err := newKeepPolicy(keepOldest, nil, false)
+	if err != nil {
+		t.Fatalf("newKeepPolicy 失败: %v", err)
+	}
+
+	a := FileEntry{Name: "a.txt", Path: "/tmp/a.txt", ModTime: 100}
+	b := FileEntry{Name: "z.txt", Path: "/tmp/z.txt", ModTime: 100}
+
+	if got := policy.criterion(a, b); got != 0 {
+		t.Fatalf("mtime 相同应该判定为打平手，criterion=%d", got)
+	}
+	// compare 仍然要给出确定性顺序用于排序，但这不代表策略本身认为二者有区别
+	if policy.compare(a, b) == 0 {
+		t.Fatalf("文件名不同时 compare 应该按字母序兜底返回非 0 结果")
+	}
+}
+
+func TestResolveKeepPromptsOnGenuineTie(t *testing.T) {
+	// first-in-priority 且没有配置任何 --priority-dir 时，任意两个文件都应该打平手
+	policy, err := newKeepPolicy(keepFirstInPriority, nil, true)
+	if err != nil {
+		t.Fatalf("newKeepPolicy 失败: %v", err)
+	}
+
+	files := []FileEntry{
+		{Name: "b.txt", Path: "/tmp/b.txt"},
+		{Name: "a.txt", Path: "/tmp/a.txt"},
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("创建 pipe 失败: %v", err)
+	}
+	origStdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = origStdin }()
+
+	go func() {
+		w.WriteString("2\n")
+		w.Close()
+	}()
+
+	// resolveKeep 先按策略排序（字母序兜底：a.txt, b.txt），打平手后提示用户选择，
+	// 输入 "2" 对应排序后列出的第二项 b.txt
+	keep := policy.resolveKeep(files)
+	if keep.Name != "b.txt" {
+		t.Fatalf("应该按用户输入选中排序后的第二个文件，got %q", keep.Name)
+	}
+}
+
+func TestResolveKeepSkipsPromptWhenNotInteractive(t *testing.T) {
+	policy, err := newKeepPolicy(keepFirstInPriority, nil, false)
+	if err != nil {
+		t.Fatalf("newKeepPolicy 失败: %v", err)
+	}
+
+	files := []FileEntry{
+		{Name: "b.txt", Path: "/tmp/b.txt"},
+		{Name: "a.txt", Path: "/tmp/a.txt"},
+	}
+
+	// 非交互模式下即使打平手也不能阻塞在 stdin 上，应该直接按排序结果返回
+	keep := policy.resolveKeep(files)
+	if keep.Name != "a.txt" {
+		t.Fatalf("非交互模式下应该按字母序兜底选择，got %q", keep.Name)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// --output / --report 支持的格式
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputCSV  = "csv"
+)
+
+// reportDocument 是 JSON 报告的顶层结构：按分组罗列 keep 和 duplicates，
+// 字段形状对应需求里的 {hash, size, keep, duplicates[]}
+type reportDocument struct {
+	Groups []reportGroup `json:"groups"`
+}
+
+type reportGroup struct {
+	Hash       string   `json:"hash"`
+	Size       int64    `json:"size"`
+	Keep       string   `json:"keep"`
+	Duplicates []string `json:"duplicates"`
+}
+
+func toReportDocument(groups []DuplicateGroup) reportDocument {
+	doc := reportDocument{Groups: make([]reportGroup, 0, len(groups))}
+	for _, g := range groups {
+		dupPaths := make([]string, 0, len(g.Duplicates))
+		for _, d := range g.Duplicates {
+			dupPaths = append(dupPaths, d.Path)
+		}
+		doc.Groups = append(doc.Groups, reportGroup{
+			Hash:       g.Hash,
+			Size:       g.Size,
+			Keep:       g.Keep.Path,
+			Duplicates: dupPaths,
+		})
+	}
+	return doc
+}
+
+// writeReport 把重复分组按 format 指定的格式写入 w，用于 stdout 输出或 --report 文件
+func writeReport(w io.Writer, groups []DuplicateGroup, format string) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(toReportDocument(groups))
+
+	case outputCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"hash", "size", "keep", "duplicate"}); err != nil {
+			return err
+		}
+		for _, g := range groups {
+			for _, d := range g.Duplicates {
+				row := []string{g.Hash, fmt.Sprintf("%d", g.Size), g.Keep.Path, d.Path}
+				if err := cw.Write(row); err != nil {
+					return err
+				}
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+
+	default: // outputText
+		i := 0
+		for _, g := range groups {
+			for _, d := range g.Duplicates {
+				i++
+				fmt.Fprintf(w, "[%d] %s (保留 %s)\n", i, d.Path, g.Keep.Path)
+			}
+		}
+		return nil
+	}
+}
+
+// writeReportFile 把重复分组写入指定路径的报告文件
+func writeReportFile(groups []DuplicateGroup, format, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return writeReport(f, groups, format)
+}
+
+// loadReportFile 从 --from-report 指定的 JSON 报告文件中恢复重复分组，
+// 让 action 阶段可以在巨型目录树上跳过重新扫描和哈希计算
+func loadReportFile(path string) ([]DuplicateGroup, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc reportDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析报告文件失败（只支持 --output=json 生成的报告）: %w", err)
+	}
+
+	groups := make([]DuplicateGroup, 0, len(doc.Groups))
+	for _, g := range doc.Groups {
+		duplicates := make([]FileEntry, 0, len(g.Duplicates))
+		for _, p := range g.Duplicates {
+			duplicates = append(duplicates, FileEntry{Name: filepath.Base(p), Path: p, Size: g.Size})
+		}
+		groups = append(groups, DuplicateGroup{
+			Hash:       g.Hash,
+			Size:       g.Size,
+			Keep:       FileEntry{Name: filepath.Base(g.Keep), Path: g.Keep, Size: g.Size},
+			Duplicates: duplicates,
+		})
+	}
+	return groups, nil
+}
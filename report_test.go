@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func sampleGroups() []DuplicateGroup {
+	return []DuplicateGroup{
+		{
+			Hash: "abc123",
+			Size: 1024,
+			Keep: FileEntry{Name: "a.txt", Path: "/tmp/a.txt", Size: 1024},
+			Duplicates: []FileEntry{
+				{Name: "b.txt", Path: "/tmp/b.txt", Size: 1024},
+				{Name: "c.txt", Path: "/tmp/c.txt", Size: 1024},
+			},
+		},
+	}
+}
+
+func TestWriteReportJSONRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.json")
+
+	if err := writeReportFile(sampleGroups(), outputJSON, path); err != nil {
+		t.Fatalf("writeReportFile 失败: %v", err)
+	}
+
+	groups, err := loadReportFile(path)
+	if err != nil {
+		t.Fatalf("loadReportFile 失败: %v", err)
+	}
+
+	if len(groups) != 1 {
+		t.Fatalf("期望 1 个分组，got %d", len(groups))
+	}
+	g := groups[0]
+	if g.Hash != "abc123" || g.Size != 1024 || g.Keep.Path != "/tmp/a.txt" {
+		t.Fatalf("分组字段不符: %+v", g)
+	}
+	if len(g.Duplicates) != 2 || g.Duplicates[0].Path != "/tmp/b.txt" || g.Duplicates[1].Path != "/tmp/c.txt" {
+		t.Fatalf("duplicates 不符: %+v", g.Duplicates)
+	}
+}
+
+func TestWriteReportCSVContainsAllRows(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeReport(&buf, sampleGroups(), outputCSV); err != nil {
+		t.Fatalf("writeReport 失败: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "hash,size,keep,duplicate\n") {
+		t.Fatalf("CSV 应该以表头开始: %q", out)
+	}
+	if !strings.Contains(out, "abc123,1024,/tmp/a.txt,/tmp/b.txt") {
+		t.Fatalf("CSV 应该包含 b.txt 这一行: %q", out)
+	}
+	if !strings.Contains(out, "abc123,1024,/tmp/a.txt,/tmp/c.txt") {
+		t.Fatalf("CSV 应该包含 c.txt 这一行: %q", out)
+	}
+}
+
+func TestWriteReportTextListsEachDuplicate(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeReport(&buf, sampleGroups(), outputText); err != nil {
+		t.Fatalf("writeReport 失败: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "/tmp/b.txt (保留 /tmp/a.txt)") {
+		t.Fatalf("文本报告应该列出 b.txt 及其保留文件: %q", out)
+	}
+	if !strings.Contains(out, "/tmp/c.txt (保留 /tmp/a.txt)") {
+		t.Fatalf("文本报告应该列出 c.txt 及其保留文件: %q", out)
+	}
+}
+
+func TestLoadReportFileRejectsInvalidJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "broken.json")
+	if err := os.WriteFile(path, []byte("不是合法的 JSON"), 0o644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+
+	if _, err := loadReportFile(path); err == nil {
+		t.Fatalf("期望解析失败时返回错误")
+	}
+}
+
+func TestLoadReportFileMissingPath(t *testing.T) {
+	if _, err := loadReportFile(filepath.Join(t.TempDir(), "not-exist.json")); err == nil {
+		t.Fatalf("期望文件不存在时返回错误")
+	}
+}
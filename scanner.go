@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// walkTree 是并发流水线的生产者：遍历目录树，把每个非目录条目（包括符号链接等
+// 非普通文件）的完整路径推送到返回的 channel 中。遍历本身保持单协程顺序执行（fs 遍历很难安全并行化），
+// 真正的并行发生在后面的哈希阶段。
+func walkTree(rootDir string) <-chan string {
+	paths := make(chan string, 256)
+
+	go func() {
+		defer close(paths)
+
+		err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				fmt.Printf("无法访问 %s: %v\n", path, err)
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			paths <- path
+			return nil
+		})
+		if err != nil {
+			fmt.Printf("遍历目录失败 %s: %v\n", rootDir, err)
+		}
+	}()
+
+	return paths
+}